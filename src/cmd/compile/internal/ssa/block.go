@@ -0,0 +1,46 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+// BlockKind is the kind of control flow a Block ends in. This package does
+// not yet implement a full pass pipeline, so only the minimal kind needed
+// by NewBlock's callers today is declared here.
+type BlockKind int8
+
+const (
+	BlockPlain BlockKind = iota
+)
+
+// A Block represents a basic block in the control flow graph of a function.
+type Block struct {
+	ID     ID
+	Kind   BlockKind
+	Values []*Value
+	Succs  []*Block
+	Preds  []*Block
+	Func   *Func
+}
+
+// AddEdgeTo records a CFG edge from b to c: c becomes a successor of b and
+// b becomes a predecessor of c.
+func (b *Block) AddEdgeTo(c *Block) {
+	b.Succs = append(b.Succs, c)
+	c.Preds = append(c.Preds, b)
+}
+
+// removeValue removes v from b's value list. It is used by cse to drop a
+// newly constructed value that turned out to already be cached, since that
+// value must not be left dangling in its block once discarded.
+func (b *Block) removeValue(v *Value) {
+	values := b.Values
+	for i, w := range values {
+		if w == v {
+			copy(values[i:], values[i+1:])
+			values[len(values)-1] = nil
+			b.Values = values[:len(values)-1]
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import "testing"
+
+func TestNewValue4(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	a0 := b.NewValue0I(0, OpConst, nil, 0)
+	a1 := b.NewValue0I(0, OpConst, nil, 1)
+	a2 := b.NewValue0I(0, OpConst, nil, 2)
+	a3 := b.NewValue0I(0, OpConst, nil, 3)
+
+	v := b.NewValue4(0, OpAdd, nil, a0, a1, a2, a3)
+	checkArgs(t, v, a0, a1, a2, a3)
+
+	vi := b.NewValue4I(0, OpAdd, nil, 7, a0, a1, a2, a3)
+	checkArgs(t, vi, a0, a1, a2, a3)
+	if vi.AuxInt != 7 {
+		t.Fatalf("NewValue4I did not set AuxInt: got %d, want 7", vi.AuxInt)
+	}
+
+	va := b.NewValue4A(0, OpAdd, nil, "aux", a0, a1, a2, a3)
+	checkArgs(t, va, a0, a1, a2, a3)
+	if va.Aux != "aux" {
+		t.Fatalf("NewValue4A did not set Aux: got %v, want %q", va.Aux, "aux")
+	}
+
+	via := b.NewValue4IA(0, OpAdd, nil, 7, "aux", a0, a1, a2, a3)
+	checkArgs(t, via, a0, a1, a2, a3)
+	if via.AuxInt != 7 || via.Aux != "aux" {
+		t.Fatalf("NewValue4IA did not set AuxInt/Aux: got %d/%v", via.AuxInt, via.Aux)
+	}
+
+	for _, a := range []*Value{a0, a1, a2, a3} {
+		if a.Uses != 4 {
+			t.Fatalf("arg %v has Uses=%d, want 4 (one per NewValue4* constructor above)", a, a.Uses)
+		}
+	}
+}
+
+func checkArgs(t *testing.T, v *Value, want ...*Value) {
+	t.Helper()
+	if len(v.Args) != len(want) {
+		t.Fatalf("len(v.Args) = %d, want %d", len(v.Args), len(want))
+	}
+	for i, a := range want {
+		if v.Args[i] != a {
+			t.Fatalf("v.Args[%d] = %v, want %v", i, v.Args[i], a)
+		}
+	}
+}
+
+func TestNewValueNInline(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	a0 := b.NewValue0I(0, OpConst, nil, 0)
+	a1 := b.NewValue0I(0, OpConst, nil, 1)
+
+	v := b.NewValueN(0, OpAdd, nil, a0, a1)
+	checkArgs(t, v, a0, a1)
+	if a0.Uses != 1 || a1.Uses != 1 {
+		t.Fatalf("NewValueN did not bump Uses on its args: a0.Uses=%d a1.Uses=%d", a0.Uses, a1.Uses)
+	}
+}
+
+// TestNewValueNSpillsToHeap exercises the arity beyond argstorage's inline
+// capacity, where NewValueN must fall back to a heap-allocated Args slice
+// instead of aliasing the fixed-size array.
+func TestNewValueNSpillsToHeap(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	var args []*Value
+	for i := 0; i < 6; i++ {
+		args = append(args, b.NewValue0I(0, OpConst, nil, int64(i)))
+	}
+
+	v := b.NewValueN(0, OpCall, nil, args...)
+	checkArgs(t, v, args...)
+	for _, a := range args {
+		if a.Uses != 1 {
+			t.Fatalf("arg %v has Uses=%d, want 1", a, a.Uses)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"log"
+	"os"
+)
+
+// Compile is the entry point for SSA-level compilation of f. This package
+// does not yet implement an optimization pass pipeline, so Compile's only
+// job today is the GOSSAFUNC=funcname debugging hook: when GOSSAFUNC names
+// f, Compile dumps f's current CFG to ssa.html via an HTMLWriter. Once this
+// package grows real passes, each one should call hw.WritePass(name) here
+// as it finishes, the way the real compiler driver does.
+func Compile(f *Func) {
+	if os.Getenv("GOSSAFUNC") != f.Name {
+		return
+	}
+	file, err := os.Create("ssa.html")
+	if err != nil {
+		log.Fatalf("can't write SSA dump: %v", err)
+	}
+	defer file.Close()
+
+	hw := NewHTMLWriter(f)
+	hw.WritePass("start")
+	hw.Close(file)
+}
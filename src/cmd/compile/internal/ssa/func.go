@@ -4,7 +4,14 @@
 
 package ssa
 
-import "log"
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+)
 
 // A Func represents a Go func declaration (or function literal) and
 // its body.  This package compiles each Func independently.
@@ -21,6 +28,26 @@ type Func struct {
 	RegAlloc []Location
 	// when stackalloc is done, the size of the stack frame
 	FrameSize int64
+
+	// constCache maps a constant to the unique Value in f.Entry that
+	// represents it, so repeated requests for the same constant return
+	// the same Value instead of allocating a new one. It is not invalidated
+	// automatically: any pass that discards f.Entry and builds a new one
+	// must call invalidateConstCache first, or ConstInt and friends will
+	// hand back Values that are no longer attached to a live block.
+	constCache map[constKey]*Value
+
+	// cseCache maps a pure value's op/type/aux/args to the canonical
+	// Value already present in the function, for use by cse.
+	cseCache map[cseKey]*Value
+}
+
+// constKey identifies a constant Value by its op, type, and aux values.
+type constKey struct {
+	op     Op
+	t      Type
+	auxint int64
+	aux    interface{}
 }
 
 // NumBlocks returns an integer larger than the id of any Block in the Func.
@@ -117,6 +144,7 @@ func (b *Block) NewValue1(line int32, op Op, t Type, arg *Value) *Value {
 	}
 	v.Args = v.argstorage[:1]
 	v.Args[0] = arg
+	arg.Uses++
 	b.Values = append(b.Values, v)
 	return v
 }
@@ -132,6 +160,7 @@ func (b *Block) NewValue1I(line int32, op Op, t Type, auxint int64, arg *Value)
 	}
 	v.Args = v.argstorage[:1]
 	v.Args[0] = arg
+	arg.Uses++
 	b.Values = append(b.Values, v)
 	return v
 }
@@ -148,6 +177,7 @@ func (b *Block) NewValue1A(line int32, op Op, t Type, aux interface{}, arg *Valu
 	}
 	v.Args = v.argstorage[:1]
 	v.Args[0] = arg
+	arg.Uses++
 	b.Values = append(b.Values, v)
 	return v
 }
@@ -164,6 +194,7 @@ func (b *Block) NewValue1IA(line int32, op Op, t Type, auxint int64, aux interfa
 	}
 	v.Args = v.argstorage[:1]
 	v.Args[0] = arg
+	arg.Uses++
 	b.Values = append(b.Values, v)
 	return v
 }
@@ -180,6 +211,8 @@ func (b *Block) NewValue2(line int32, op Op, t Type, arg0, arg1 *Value) *Value {
 	v.Args = v.argstorage[:2]
 	v.Args[0] = arg0
 	v.Args[1] = arg1
+	arg0.Uses++
+	arg1.Uses++
 	b.Values = append(b.Values, v)
 	return v
 }
@@ -193,13 +226,352 @@ func (b *Block) NewValue3(line int32, op Op, t Type, arg0, arg1, arg2 *Value) *V
 		Block: b,
 		Line:  line,
 	}
-	v.Args = []*Value{arg0, arg1, arg2}
+	v.Args = v.argstorage[:3]
+	v.Args[0] = arg0
+	v.Args[1] = arg1
+	v.Args[2] = arg2
+	arg0.Uses++
+	arg1.Uses++
+	arg2.Uses++
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// NewValue4 returns a new value in the block with four arguments and zero aux values.
+func (b *Block) NewValue4(line int32, op Op, t Type, arg0, arg1, arg2, arg3 *Value) *Value {
+	v := &Value{
+		ID:    b.Func.vid.get(),
+		Op:    op,
+		Type:  t,
+		Block: b,
+		Line:  line,
+	}
+	v.Args = v.argstorage[:4]
+	v.Args[0] = arg0
+	v.Args[1] = arg1
+	v.Args[2] = arg2
+	v.Args[3] = arg3
+	arg0.Uses++
+	arg1.Uses++
+	arg2.Uses++
+	arg3.Uses++
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// NewValue4I returns a new value in the block with four arguments and an auxint value.
+func (b *Block) NewValue4I(line int32, op Op, t Type, auxint int64, arg0, arg1, arg2, arg3 *Value) *Value {
+	v := &Value{
+		ID:     b.Func.vid.get(),
+		Op:     op,
+		Type:   t,
+		AuxInt: auxint,
+		Block:  b,
+		Line:   line,
+	}
+	v.Args = v.argstorage[:4]
+	v.Args[0] = arg0
+	v.Args[1] = arg1
+	v.Args[2] = arg2
+	v.Args[3] = arg3
+	arg0.Uses++
+	arg1.Uses++
+	arg2.Uses++
+	arg3.Uses++
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// NewValue4A returns a new value in the block with four arguments and an aux value.
+func (b *Block) NewValue4A(line int32, op Op, t Type, aux interface{}, arg0, arg1, arg2, arg3 *Value) *Value {
+	v := &Value{
+		ID:    b.Func.vid.get(),
+		Op:    op,
+		Type:  t,
+		Aux:   aux,
+		Block: b,
+		Line:  line,
+	}
+	v.Args = v.argstorage[:4]
+	v.Args[0] = arg0
+	v.Args[1] = arg1
+	v.Args[2] = arg2
+	v.Args[3] = arg3
+	arg0.Uses++
+	arg1.Uses++
+	arg2.Uses++
+	arg3.Uses++
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// NewValue4IA returns a new value in the block with four arguments and both an auxint and aux values.
+func (b *Block) NewValue4IA(line int32, op Op, t Type, auxint int64, aux interface{}, arg0, arg1, arg2, arg3 *Value) *Value {
+	v := &Value{
+		ID:     b.Func.vid.get(),
+		Op:     op,
+		Type:   t,
+		AuxInt: auxint,
+		Aux:    aux,
+		Block:  b,
+		Line:   line,
+	}
+	v.Args = v.argstorage[:4]
+	v.Args[0] = arg0
+	v.Args[1] = arg1
+	v.Args[2] = arg2
+	v.Args[3] = arg3
+	arg0.Uses++
+	arg1.Uses++
+	arg2.Uses++
+	arg3.Uses++
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// NewValueN returns a new value in the block with an arbitrary number of
+// arguments and zero aux values. Use this for ops whose arity exceeds what
+// NewValue0..NewValue4 cover, or whose arity varies (e.g. calls, phis).
+// Args up to len(argstorage) are still stored inline; beyond that they
+// spill to a heap-allocated slice.
+func (b *Block) NewValueN(line int32, op Op, t Type, args ...*Value) *Value {
+	v := &Value{
+		ID:    b.Func.vid.get(),
+		Op:    op,
+		Type:  t,
+		Block: b,
+		Line:  line,
+	}
+	if len(args) <= len(v.argstorage) {
+		v.Args = v.argstorage[:len(args)]
+	} else {
+		v.Args = make([]*Value, len(args))
+	}
+	copy(v.Args, args)
+	for _, a := range args {
+		a.Uses++
+	}
 	b.Values = append(b.Values, v)
 	return v
 }
 
 // ConstInt returns an int constant representing its argument.
 func (f *Func) ConstInt(line int32, t Type, c int64) *Value {
-	// TODO: cache?
-	return f.Entry.NewValue0I(line, OpConst, t, c)
-}
\ No newline at end of file
+	return f.constVal(line, OpConst, t, c, nil)
+}
+
+// ConstBool returns a bool constant representing its argument.
+func (f *Func) ConstBool(line int32, t Type, c bool) *Value {
+	i := int64(0)
+	if c {
+		i = 1
+	}
+	return f.constVal(line, OpConstBool, t, i, nil)
+}
+
+// ConstFloat32 returns a float32 constant representing its argument.
+func (f *Func) ConstFloat32(line int32, t Type, c float64) *Value {
+	// Round to float32 precision before hashing so that two float64 values
+	// that agree once rounded share the same cached Value.
+	return f.constVal(line, OpConstFloat32, t, int64(math.Float64bits(float64(float32(c)))), nil)
+}
+
+// ConstFloat64 returns a float64 constant representing its argument.
+func (f *Func) ConstFloat64(line int32, t Type, c float64) *Value {
+	return f.constVal(line, OpConstFloat64, t, int64(math.Float64bits(c)), nil)
+}
+
+// ConstString returns a string constant representing its argument.
+func (f *Func) ConstString(line int32, t Type, s string) *Value {
+	return f.constVal(line, OpConstString, t, 0, s)
+}
+
+// ConstNil returns a nil constant of the given type.
+func (f *Func) ConstNil(line int32, t Type) *Value {
+	return f.constVal(line, OpConstNil, t, 0, nil)
+}
+
+// ConstSlice returns a nil-slice constant of the given type.
+func (f *Func) ConstSlice(line int32, t Type) *Value {
+	return f.constVal(line, OpConstSlice, t, 0, nil)
+}
+
+// constVal returns the unique Value for the given constant, allocating it
+// in f.Entry the first time it is requested.
+func (f *Func) constVal(line int32, op Op, t Type, auxint int64, aux interface{}) *Value {
+	if f.constCache == nil {
+		f.constCache = make(map[constKey]*Value)
+	}
+	key := constKey{op, t, auxint, aux}
+	if v := f.constCache[key]; v != nil {
+		return v
+	}
+	var v *Value
+	if aux == nil {
+		v = f.Entry.NewValue0I(line, op, t, auxint)
+	} else {
+		v = f.Entry.NewValue0IA(line, op, t, auxint, aux)
+	}
+	f.constCache[key] = v
+	return v
+}
+
+// invalidateConstCache discards f's constant and cse caches. Callers that
+// rebuild f.Entry wholesale must call this first, since Values cached
+// against the old entry block are no longer safe to hand out.
+func (f *Func) invalidateConstCache() {
+	f.constCache = nil
+	f.cseCache = nil
+}
+
+// cseKey identifies a pure value (one with no side effects) by its op,
+// type, aux values and arguments, for use by cse.
+type cseKey struct {
+	op     Op
+	t      Type
+	auxint int64
+	aux    interface{}
+	nargs  int
+	args   [4]ID
+}
+
+// cse returns the canonical Value to use in place of v: if a previously
+// recorded pure value exists with the same op, type, aux values and args,
+// that value is returned and v is unlinked from its block, dropping the
+// Uses it had put on its own args (v itself never existed as far as any
+// caller of cse is concerned); otherwise v itself is recorded and returned.
+// It is the caller's responsibility to only call cse on side-effect-free
+// ops (no loads, stores, or calls), and to dedupe within a single pass over
+// the function, the same way the entry-block constant cache above is kept
+// per-Func rather than per-call.
+//
+// Note that by the time cse is called, v's ID has already been allocated by
+// its NewValue* constructor, so unlike the constCache above, deduplicating
+// here does not shrink Func.NumValues() — it only keeps the discarded Value
+// out of its block's Values list.
+func (f *Func) cse(v *Value) *Value {
+	if len(v.Args) > 4 {
+		// Too many args to key on cheaply; don't dedupe.
+		return v
+	}
+	if f.cseCache == nil {
+		f.cseCache = make(map[cseKey]*Value)
+	}
+	key := cseKey{op: v.Op, t: v.Type, auxint: v.AuxInt, aux: v.Aux, nargs: len(v.Args)}
+	for i, a := range v.Args {
+		key.args[i] = a.ID
+	}
+	if old := f.cseCache[key]; old != nil {
+		for _, a := range v.Args {
+			a.Uses--
+		}
+		v.Block.removeValue(v)
+		return old
+	}
+	f.cseCache[key] = v
+	return v
+}
+
+// String returns f in the same textual form that WriteTo produces.
+func (f *Func) String() string {
+	var buf bytes.Buffer
+	f.WriteTo(&buf)
+	return buf.String()
+}
+
+// WriteTo writes a stable, diff-friendly textual form of f to w, one block
+// per line group, values listed in the order they appear in the block, e.g.
+//
+//	b1:
+//	    v1 = Const <int> [3]
+//	    v2 = Add <int> [0] v1 v1
+//
+// Blocks are printed in reverse postorder from f.Entry when CFG successor
+// edges (Block.Succs) are available; blocks unreachable that way fall back
+// to id order so WriteTo never silently drops a block.
+func (f *Func) WriteTo(w io.Writer) {
+	blocks := f.rpoBlocks()
+	for _, b := range blocks {
+		fmt.Fprintf(w, "b%d:\n", b.ID)
+		for _, v := range b.Values {
+			fmt.Fprintf(w, "    v%d = %s <%s>", v.ID, v.Op, v.Type)
+			// AuxInt is printed unconditionally: zero is a legitimate value
+			// for ops like Const, and omitting it would make that
+			// indistinguishable from a value with no aux data at all.
+			fmt.Fprintf(w, " [%d]", v.AuxInt)
+			if v.Aux != nil {
+				fmt.Fprintf(w, " {%v}", v.Aux)
+			}
+			for _, a := range v.Args {
+				fmt.Fprintf(w, " v%d", a.ID)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// blocksByID sorts blocks by ID for a stable, diff-friendly print order.
+type blocksByID []*Block
+
+func (x blocksByID) Len() int           { return len(x) }
+func (x blocksByID) Less(i, j int) bool { return x[i].ID < x[j].ID }
+func (x blocksByID) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+// rpoBlocks returns f's blocks in reverse postorder starting from f.Entry,
+// walking Block.Succs. Any blocks f.Entry can't reach that way (including
+// all of them, if f.Entry is nil or no edges have been recorded yet) are
+// appended afterward in id order, so every block in f.Blocks is still
+// printed exactly once.
+func (f *Func) rpoBlocks() []*Block {
+	seen := make(map[*Block]bool)
+	var postorder []*Block
+	if f.Entry != nil {
+		var visit func(b *Block)
+		visit = func(b *Block) {
+			if seen[b] {
+				return
+			}
+			seen[b] = true
+			for _, s := range b.Succs {
+				visit(s)
+			}
+			postorder = append(postorder, b)
+		}
+		visit(f.Entry)
+	}
+	order := make([]*Block, 0, len(f.Blocks))
+	for i := len(postorder) - 1; i >= 0; i-- {
+		order = append(order, postorder[i])
+	}
+	var rest []*Block
+	for _, b := range f.Blocks {
+		if !seen[b] {
+			rest = append(rest, b)
+		}
+	}
+	sort.Sort(blocksByID(rest))
+	return append(order, rest...)
+}
+
+// checkUses verifies that every Value's Uses count matches the number of
+// times it actually appears as an argument somewhere in f. It is meant for
+// use from tests, to catch constructors or rewrites that forgot to keep
+// Uses up to date.
+func (f *Func) checkUses() error {
+	counts := map[*Value]int32{}
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			for _, a := range v.Args {
+				counts[a]++
+			}
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			if v.Uses != counts[v] {
+				return fmt.Errorf("v%d has Uses=%d, want %d", v.ID, v.Uses, counts[v])
+			}
+		}
+	}
+	return nil
+}
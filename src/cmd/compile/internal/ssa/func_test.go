@@ -0,0 +1,92 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import "testing"
+
+func testFunc(name string) *Func {
+	f := &Func{Name: name}
+	f.Entry = f.NewBlock(BlockPlain)
+	return f
+}
+
+func TestConstCache(t *testing.T) {
+	f := testFunc("f")
+	before := f.NumValues()
+	v1 := f.ConstInt(0, nil, 3)
+	v2 := f.ConstInt(0, nil, 3)
+	if v1 != v2 {
+		t.Fatalf("ConstInt(3) returned distinct Values: %v != %v", v1, v2)
+	}
+	if n := f.NumValues(); n != before+1 {
+		t.Fatalf("NumValues() = %d, want %d after caching a repeated constant", n, before+1)
+	}
+	v3 := f.ConstInt(0, nil, 4)
+	if v3 == v1 {
+		t.Fatal("ConstInt(4) incorrectly returned the Value cached for 3")
+	}
+}
+
+func TestConstFloat32Rounding(t *testing.T) {
+	f := testFunc("f")
+	// c1 and c2 differ only below float32 precision, so once rounded they
+	// must share the same cached Value.
+	const c1 = 1.0000000001
+	const c2 = 1.0000000002
+	v1 := f.ConstFloat32(0, nil, c1)
+	v2 := f.ConstFloat32(0, nil, c2)
+	if v1 != v2 {
+		t.Fatal("ConstFloat32 did not dedupe two values equal at float32 precision")
+	}
+}
+
+// TestCSEDoesNotShrinkNumValues documents a known limitation: cse runs
+// after its argument's ID has already been allocated, so it can dedupe
+// Values within a block but cannot reduce Func.NumValues() the way the
+// upfront constCache check in constVal does.
+func TestCSEDoesNotShrinkNumValues(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	before := f.NumValues()
+	v1 := b.NewValue0I(0, OpConst, nil, 5)
+	v2 := b.NewValue0I(0, OpConst, nil, 5)
+	f.cse(v1) // record v1 so cse has something to dedupe v2 against
+	if got := f.cse(v2); got != v1 {
+		t.Fatal("cse did not dedupe two identical Values")
+	}
+	if n := f.NumValues(); n != before+2 {
+		t.Fatalf("NumValues() = %d, want %d (cse cannot shrink it)", n, before+2)
+	}
+	if len(b.Values) != 1 {
+		t.Fatalf("len(b.Values) = %d, want 1 (cse should drop the duplicate)", len(b.Values))
+	}
+}
+
+// TestCSEDropsUsesOnDiscardedArgs guards against cse unlinking a discarded
+// duplicate from its block while leaving the Uses its constructor already
+// put on its own args in place, which would inflate those args' Uses
+// forever and break Func.checkUses.
+func TestCSEDropsUsesOnDiscardedArgs(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	a := b.NewValue0I(0, OpConst, nil, 1)
+
+	v1 := b.NewValue2(0, OpAdd, nil, a, a)
+	v2 := b.NewValue2(0, OpAdd, nil, a, a)
+	if a.Uses != 4 {
+		t.Fatalf("a.Uses = %d, want 4 before cse", a.Uses)
+	}
+
+	f.cse(v1) // record v1 so cse has something to dedupe v2 against
+	if got := f.cse(v2); got != v1 {
+		t.Fatal("cse did not dedupe two identical OpAdd(a, a) Values")
+	}
+	if a.Uses != 2 {
+		t.Fatalf("a.Uses = %d, want 2 after cse discarded v2", a.Uses)
+	}
+	if err := f.checkUses(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDotHasEdges(t *testing.T) {
+	f := testFunc("f")
+	b2 := f.NewBlock(BlockPlain)
+	f.Entry.AddEdgeTo(b2)
+
+	dot := f.dot()
+	want := dotID(f.Entry) + " -> " + dotID(b2)
+	if !strings.Contains(dot, want) {
+		t.Fatalf("dot() missing edge %q:\n%s", want, dot)
+	}
+}
+
+func TestHTMLWriterSnapshotsDiffer(t *testing.T) {
+	f := testFunc("f")
+	hw := NewHTMLWriter(f)
+	hw.WritePass("before")
+	f.Entry.NewValue0I(0, OpConst, nil, 1)
+	hw.WritePass("after")
+
+	var buf bytes.Buffer
+	hw.Close(&buf)
+	out := buf.String()
+
+	before := strings.Index(out, "before")
+	after := strings.Index(out, "after")
+	if before < 0 || after < 0 {
+		t.Fatalf("both pass names should appear in output:\n%s", out)
+	}
+	beforeDot := out[before:after]
+	afterDot := out[after:]
+	// Compare only the <pre> bodies, so the differing pass names alone
+	// cannot make the snapshots look different.
+	beforeDot = beforeDot[strings.Index(beforeDot, "<pre>"):strings.Index(beforeDot, "</pre>")]
+	afterDot = afterDot[strings.Index(afterDot, "<pre>"):strings.Index(afterDot, "</pre>")]
+	if beforeDot == afterDot {
+		t.Fatal("snapshots for different passes should not be identical once the IR has changed")
+	}
+}
+
+func TestFuncWriteHTML(t *testing.T) {
+	f := testFunc("f")
+	f.Entry.NewValue0I(0, OpConst, nil, 1)
+
+	var buf bytes.Buffer
+	f.WriteHTML(&buf, "start", "end")
+	out := buf.String()
+
+	if !strings.Contains(out, "start") || !strings.Contains(out, "end") {
+		t.Fatalf("WriteHTML should label a section per pass name:\n%s", out)
+	}
+	if !strings.Contains(out, f.dot()) {
+		t.Fatalf("WriteHTML should render f's current CFG:\n%s", out)
+	}
+}
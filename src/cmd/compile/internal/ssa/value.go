@@ -0,0 +1,82 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+// ID is used to give each Block and Value a unique, small (dense) integer
+// identifier within a Func.
+type ID int32
+
+// A Value represents a value in the SSA representation of the program.
+// The ID and Type fields must not be modified. The remainder may be modified
+// if they preserve the value of the Value (e.g. changing a (mul 2 x) to
+// (add x x)).
+type Value struct {
+	// A unique identifier for the value. For performance we allocate these
+	// sequentially.
+	ID ID
+
+	// The operation that computes this value. See op.go.
+	Op Op
+
+	// The type of this value. Normally this will be a Go type, but there
+	// are a few other pseudo-types, see type.go.
+	Type Type
+
+	// Extra constant data, for some opcodes. Shared by each opcode
+	// according to its meaning, not to save space.
+	AuxInt int64
+	Aux    interface{}
+
+	// Arguments of this value.
+	Args []*Value
+
+	// Line number for value.
+	Line int32
+
+	// The block this value is in.
+	Block *Block
+
+	// Storage for the first few args, so we don't need to allocate a
+	// slice for the common case of a value with few args.
+	argstorage [4]*Value
+
+	// Uses is the number of places in other Values' Args that reference
+	// this Value. It is maintained by the
+	// NewValue* constructors and by SetArg/removeArg/reset, so passes like
+	// dead-code elimination can tell when a Value has become unreferenced
+	// without having to rescan the whole function.
+	Uses int32
+}
+
+// SetArg sets the i'th argument of v to newArg, adjusting Uses on both the
+// value being replaced and newArg.
+func (v *Value) SetArg(i int, newArg *Value) {
+	old := v.Args[i]
+	v.Args[i] = newArg
+	newArg.Uses++
+	old.Uses--
+}
+
+// removeArg removes the i'th argument of v, adjusting Uses and shifting
+// any later arguments down by one.
+func (v *Value) removeArg(i int) {
+	v.Args[i].Uses--
+	copy(v.Args[i:], v.Args[i+1:])
+	v.Args[len(v.Args)-1] = nil
+	v.Args = v.Args[:len(v.Args)-1]
+}
+
+// reset changes v's op to op and clears out all its arguments, dropping a
+// Use on each of them. Callers must set any new op-specific fields (AuxInt,
+// Aux) and args themselves after calling reset.
+func (v *Value) reset(op Op) {
+	for _, a := range v.Args {
+		a.Uses--
+	}
+	v.Op = op
+	v.AuxInt = 0
+	v.Aux = nil
+	v.Args = v.argstorage[:0]
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import "testing"
+
+func TestUses(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	a := b.NewValue0I(0, OpConst, nil, 1)
+	c := b.NewValue0I(0, OpConst, nil, 2)
+	v := b.NewValue2(0, OpAdd, nil, a, c)
+	if a.Uses != 1 || c.Uses != 1 {
+		t.Fatalf("NewValue2 did not bump Uses on its args: a.Uses=%d c.Uses=%d", a.Uses, c.Uses)
+	}
+	if err := f.checkUses(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := b.NewValue0I(0, OpConst, nil, 3)
+	v.SetArg(1, d)
+	if c.Uses != 0 {
+		t.Fatalf("SetArg did not drop the replaced arg's Uses: c.Uses=%d", c.Uses)
+	}
+	if d.Uses != 1 {
+		t.Fatalf("SetArg did not bump the new arg's Uses: d.Uses=%d", d.Uses)
+	}
+	if err := f.checkUses(); err != nil {
+		t.Fatal(err)
+	}
+
+	v.removeArg(1)
+	if d.Uses != 0 {
+		t.Fatalf("removeArg did not drop Uses: d.Uses=%d", d.Uses)
+	}
+	if len(v.Args) != 1 {
+		t.Fatalf("removeArg did not shrink Args: len=%d", len(v.Args))
+	}
+	if err := f.checkUses(); err != nil {
+		t.Fatal(err)
+	}
+
+	v.reset(OpConst)
+	if a.Uses != 0 {
+		t.Fatalf("reset did not drop Uses on the remaining arg: a.Uses=%d", a.Uses)
+	}
+	if len(v.Args) != 0 {
+		t.Fatalf("reset did not clear Args: len=%d", len(v.Args))
+	}
+	if err := f.checkUses(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckUsesCatchesMismatch(t *testing.T) {
+	f := testFunc("f")
+	b := f.Entry
+	a := b.NewValue0I(0, OpConst, nil, 1)
+	b.NewValue1(0, OpNeg, nil, a)
+
+	a.Uses++ // corrupt the count directly, bypassing SetArg/removeArg
+	if err := f.checkUses(); err == nil {
+		t.Fatal("checkUses did not catch a corrupted Uses count")
+	}
+}